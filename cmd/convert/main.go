@@ -0,0 +1,26 @@
+// Command convert rewrites the front matter of every markdown file under a
+// PostPath into a different format, mirroring Hugo's "hugo convert".
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/wujiyu115/yuques/deal"
+	"github.com/wujiyu115/yuques/parser/metadecoders"
+)
+
+func main() {
+	postPath := flag.String("postPath", "yuque", "directory to convert front matter in")
+	to := flag.String("to", "yaml", "target front matter format (yaml, toml or json)")
+	flag.Parse()
+
+	format := metadecoders.FormatFromString(*to)
+	if format == "" {
+		log.Fatalf("unsupported front matter format %q", *to)
+	}
+
+	if err := deal.ConvertFrontMatter(*postPath, format); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,70 +1,159 @@
 package deal
 
 import (
-	"encoding/json"
+	"bytes"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 
-	"github.com/jinzhu/copier"
-	"gopkg.in/yaml.v2"
+	"github.com/pkg/errors"
+
+	"github.com/wujiyu115/yuques/parser/metadecoders"
 )
 
+// configPath, when set via -config, overrides the default config.<ext>
+// discovery below with an explicit path. Its format is still auto-detected
+// from content, not from the path's extension.
+var configPath = flag.String("config", "", "path to the sync config file (YAML, TOML or JSON; format is auto-detected)")
+
 //SyncConfig of sync
 type SyncConfig struct {
-	Token       string `json:"token" yaml:"token"`
-	Login       string `json:"login" yaml:"login"`
-	Repo        string `json:"repo" yaml:"repo"`
-	PostPath    string `json:"postPath" yaml:"postPath"`
-	CachePath   string `json:"cachePath" yaml:"cachePath"`
-	MdFormat    string `json:"mdFormat" yaml:"mdFormat"`
-	Concurrency int    `json:"concurrency" yaml:"concurrency"`
-	OnlyPub     bool   `json:"onlyPub" yaml:"onlyPub"`
-	Adapter     string `json:"adapter" yaml:"adapter"`
+	Token             string `json:"token" yaml:"token"`
+	Login             string `json:"login" yaml:"login"`
+	Repo              string `json:"repo" yaml:"repo"`
+	PostPath          string `json:"postPath" yaml:"postPath"`
+	CachePath         string `json:"cachePath" yaml:"cachePath"`
+	MdFormat          string `json:"mdFormat" yaml:"mdFormat"`
+	Concurrency       int    `json:"concurrency" yaml:"concurrency"`
+	OnlyPub           bool   `json:"onlyPub" yaml:"onlyPub"`
+	Adapter           string `json:"adapter" yaml:"adapter"`
+	FrontMatterFormat string `json:"frontMatterFormat" yaml:"frontMatterFormat"`
 }
 
 var defaultConfig = SyncConfig{
-	Token:       "",
-	Login:       "",
-	Repo:        "",
-	PostPath:    "yuque",
-	CachePath:   "yuque.json",
-	MdFormat:    "Title",
-	Adapter:     "markdown",
-	Concurrency: 5,
-	OnlyPub:     true,
+	Token:             "",
+	Login:             "",
+	Repo:              "",
+	PostPath:          "yuque",
+	CachePath:         "yuque.json",
+	MdFormat:          "Title",
+	Adapter:           "markdown",
+	Concurrency:       5,
+	OnlyPub:           true,
+	FrontMatterFormat: "yaml",
+}
+
+//SyncConfigFile is the shape of a config file on disk. Repos lets one file
+//describe several Yuque repos - different Login/Repo/PostPath - sharing a
+//single Defaults layer (Token, Concurrency, ...).
+type SyncConfigFile struct {
+	Defaults SyncConfig   `json:"defaults" yaml:"defaults"`
+	Repos    []SyncConfig `json:"repos" yaml:"repos"`
 }
 
-func loadJSON(cfg *SyncConfig) error {
-	jsonFile, err := os.Open("config.json")
+// locateConfigData finds the sync config file - the -config path if one was
+// given, otherwise the first "config.<ext>" matching a registered format -
+// and returns its raw bytes (BOM stripped) together with the FormatHandler
+// detected from its content.
+func locateConfigData() ([]byte, metadecoders.FormatHandler, error) {
+	path := *configPath
+	if path == "" {
+		for _, h := range metadecoders.RegisteredFormats() {
+			for _, ext := range h.Extensions {
+				candidate := "config." + ext
+				if _, err := os.Stat(candidate); err == nil {
+					path = candidate
+					break
+				}
+			}
+			if path != "" {
+				break
+			}
+		}
+	}
+	if path == "" {
+		return nil, metadecoders.FormatHandler{}, os.ErrNotExist
+	}
+
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, metadecoders.FormatHandler{}, err
 	}
-	defer jsonFile.Close()
-	byteValue, _ := ioutil.ReadAll(jsonFile)
-	return json.Unmarshal(byteValue, &cfg)
-}
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
 
-func loadYaml(cfg *SyncConfig) error {
-	jsonFile, err := os.Open("config.yaml")
+	format, err := metadecoders.Default.FormatFromContentString(string(data))
 	if err != nil {
-		return err
+		return nil, metadecoders.FormatHandler{}, errors.Wrapf(err, "failed to detect the format of config file %q", path)
 	}
-	defer jsonFile.Close()
-	byteValue, _ := ioutil.ReadAll(jsonFile)
-	return yaml.Unmarshal(byteValue, &cfg)
+
+	handler, ok := metadecoders.HandlerFor(format)
+	if !ok {
+		return nil, metadecoders.FormatHandler{}, errors.Errorf("no decoder registered for detected format %q", format)
+	}
+
+	return data, handler, nil
 }
 
-//LoadConfig of sync
-func LoadConfig() SyncConfig {
-	var (
-		cfg SyncConfig
-	)
-	if err := loadJSON(&cfg); err != nil {
-		loadYaml(&cfg)
+//LoadConfig loads the sync config and returns one SyncConfig per repo. A
+//config file with a top-level "repos" list describes multiple Yuque repos;
+//each entry is produced by overlaying onto Defaults only the fields it
+//actually sets (via applyConfigMap, decoded from the raw map rather than a
+//struct), so an explicit zero value such as "onlyPub: false" or
+//"concurrency: 0" overrides Defaults instead of being mistaken for "unset"
+//the way copier's IgnoreEmpty option would. A flat config file with no
+//"repos" (the pre-multi-repo shape) is treated the same way against
+//defaultConfig and returned as the lone entry, so existing configs keep
+//working unchanged.
+func LoadConfig() []SyncConfig {
+	data, handler, err := locateConfigData()
+	if err != nil {
+		return []SyncConfig{defaultConfig}
+	}
+
+	raw, err := metadecoders.Default.UnmarshalToMap(data, handler.Format)
+	if err != nil {
+		return []SyncConfig{defaultConfig}
+	}
+
+	reposRaw, hasRepos := raw["repos"]
+	if !hasRepos {
+		merged := defaultConfig
+		if err := applyConfigMap(&merged, raw); err != nil {
+			return []SyncConfig{defaultConfig}
+		}
+		return []SyncConfig{merged}
+	}
+
+	defaults := defaultConfig
+	if defaultsRaw, ok := raw["defaults"].(map[string]interface{}); ok {
+		if err := applyConfigMap(&defaults, defaultsRaw); err != nil {
+			return []SyncConfig{defaultConfig}
+		}
+	}
+
+	var repoMaps []interface{}
+	switch rs := reposRaw.(type) {
+	case []interface{}:
+		repoMaps = rs
+	case []map[string]interface{}:
+		for _, m := range rs {
+			repoMaps = append(repoMaps, m)
+		}
+	}
+	repos := make([]SyncConfig, 0, len(repoMaps))
+	for _, r := range repoMaps {
+		repoRaw, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		merged := defaults
+		if err := applyConfigMap(&merged, repoRaw); err != nil {
+			continue
+		}
+		repos = append(repos, merged)
 	}
-	copier.Copy(&defaultConfig, &cfg)
-	return defaultConfig
+	return repos
 }
 
 //GenNameSpace gen namespace
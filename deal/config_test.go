@@ -0,0 +1,86 @@
+package deal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func withConfigPath(t *testing.T, path string) func() {
+	t.Helper()
+	old := *configPath
+	*configPath = path
+	return func() { *configPath = old }
+}
+
+func TestLoadConfigMergeRespectsZeroOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yuques-config-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+defaults:
+  token: abc
+  onlyPub: true
+  concurrency: 5
+repos:
+  - login: a
+    repo: r1
+  - login: b
+    repo: r2
+    onlyPub: false
+    concurrency: 0
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	defer withConfigPath(t, path)()
+
+	repos := LoadConfig()
+	if len(repos) != 2 {
+		t.Fatalf("len(repos) = %d, want 2", len(repos))
+	}
+
+	r1, r2 := repos[0], repos[1]
+	if r1.Login != "a" || !r1.OnlyPub || r1.Concurrency != 5 {
+		t.Errorf("repo1 = %+v, want inherited OnlyPub=true Concurrency=5", r1)
+	}
+	if r2.Login != "b" || r2.OnlyPub || r2.Concurrency != 0 {
+		t.Errorf("repo2 = %+v, want explicit OnlyPub=false Concurrency=0 to override defaults", r2)
+	}
+	if r1.Token != "abc" || r2.Token != "abc" {
+		t.Errorf("both repos should inherit Defaults.Token, got %q and %q", r1.Token, r2.Token)
+	}
+}
+
+func TestLoadConfigFlatFileHasNoRepos(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yuques-config-test")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	content := "token: abc\nonlyPub: false\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	defer withConfigPath(t, path)()
+
+	repos := LoadConfig()
+	if len(repos) != 1 {
+		t.Fatalf("len(repos) = %d, want 1", len(repos))
+	}
+	if repos[0].Token != "abc" || repos[0].OnlyPub {
+		t.Errorf("repo = %+v, want Token=abc OnlyPub=false", repos[0])
+	}
+	if repos[0].Concurrency != defaultConfig.Concurrency {
+		t.Errorf("Concurrency = %d, want inherited default %d", repos[0].Concurrency, defaultConfig.Concurrency)
+	}
+}
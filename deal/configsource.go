@@ -0,0 +1,200 @@
+package deal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+
+	"github.com/wujiyu115/yuques/parser/metadecoders"
+)
+
+// ConfigSource produces a raw set of config overrides, keyed by SyncConfig's
+// json tags. LoadConfigLayered applies sources in order, and each source
+// overrides only the keys it actually returns, so later layers never clobber
+// fields an earlier layer set but a later one left untouched.
+type ConfigSource func() (map[string]interface{}, error)
+
+// DefaultsSource seeds a layered config with the package defaults. It is
+// normally the first source passed to LoadConfigLayered.
+func DefaultsSource() (map[string]interface{}, error) {
+	return configToMap(defaultConfig), nil
+}
+
+// FileSource loads path, auto-detecting its format the same way LoadConfig
+// does, and decodes it into a raw map rather than a SyncConfig directly so
+// LoadConfigLayered can tell exactly which fields the file set.
+func FileSource(path string) ConfigSource {
+	return func() (map[string]interface{}, error) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+
+		format, err := metadecoders.Default.FormatFromContentString(string(data))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to detect the format of config file %q", path)
+		}
+
+		return metadecoders.Default.UnmarshalToMap(data, format)
+	}
+}
+
+// EnvSource reads environment variables starting with prefix (e.g.
+// "YUQUE_TOKEN", "YUQUE_CONCURRENCY") and maps them onto SyncConfig fields,
+// using metadecoders.Decoder.UnmarshalStringTo - the same cast.ToIntE /
+// cast.ToBoolE based coercion metadecoders already uses for front matter
+// scalars - to convert the raw string value to the field's type.
+func EnvSource(prefix string) ConfigSource {
+	return func() (map[string]interface{}, error) {
+		out := make(map[string]interface{})
+
+		for _, kv := range os.Environ() {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 || !strings.HasPrefix(parts[0], prefix) {
+				continue
+			}
+			name, value := parts[0], parts[1]
+
+			tag, zero, ok := configField(strings.TrimPrefix(name, prefix))
+			if !ok {
+				continue
+			}
+
+			v, err := metadecoders.Default.UnmarshalStringTo(value, zero)
+			if err != nil {
+				return nil, errors.Wrapf(err, "env var %s", name)
+			}
+			out[tag] = v
+		}
+
+		return out, nil
+	}
+}
+
+// FlagSource reads the flags that were explicitly set on fs and maps them
+// onto SyncConfig fields by name (e.g. a "-concurrency" flag maps to the
+// Concurrency field), using the same UnmarshalStringTo coercion as
+// EnvSource. Flags left at their default are not visited, so they never
+// override an earlier layer.
+func FlagSource(fs *pflag.FlagSet) ConfigSource {
+	return func() (map[string]interface{}, error) {
+		out := make(map[string]interface{})
+
+		var visitErr error
+		fs.Visit(func(f *pflag.Flag) {
+			if visitErr != nil {
+				return
+			}
+
+			tag, zero, ok := configField(f.Name)
+			if !ok {
+				return
+			}
+
+			v, err := metadecoders.Default.UnmarshalStringTo(f.Value.String(), zero)
+			if err != nil {
+				visitErr = errors.Wrapf(err, "flag -%s", f.Name)
+				return
+			}
+			out[tag] = v
+		})
+
+		return out, visitErr
+	}
+}
+
+// LoadConfigLayered builds a SyncConfig by applying sources in order -
+// typically DefaultsSource, then FileSource, then EnvSource, then
+// FlagSource - with each later source overriding only the fields it
+// explicitly set.
+func LoadConfigLayered(sources ...ConfigSource) (SyncConfig, error) {
+	var cfg SyncConfig
+
+	for _, src := range sources {
+		raw, err := src()
+		if err != nil {
+			return cfg, err
+		}
+		if err := applyConfigMap(&cfg, raw); err != nil {
+			return cfg, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// configToMap reflects cfg's fields into a map keyed by their json tag.
+func configToMap(cfg SyncConfig) map[string]interface{} {
+	out := make(map[string]interface{})
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		out[t.Field(i).Tag.Get("json")] = v.Field(i).Interface()
+	}
+	return out
+}
+
+// configField looks up the SyncConfig field whose json tag matches name,
+// case-insensitively (so "POSTPATH", "postpath" and "postPath" all resolve
+// to the "postPath" field), and returns that tag in its canonical case
+// together with the field's zero value, which callers use to hint
+// UnmarshalStringTo at the type the raw value needs to be coerced to.
+func configField(name string) (tag string, zero interface{}, ok bool) {
+	t := reflect.TypeOf(SyncConfig{})
+	lower := strings.ToLower(name)
+	for i := 0; i < t.NumField(); i++ {
+		fieldTag := t.Field(i).Tag.Get("json")
+		if strings.ToLower(fieldTag) == lower {
+			return fieldTag, reflect.Zero(t.Field(i).Type).Interface(), true
+		}
+	}
+	return "", nil, false
+}
+
+// applyConfigMap sets the fields of cfg present in raw (keyed by json tag),
+// coercing each value to the destination field's type via cast.
+func applyConfigMap(cfg *SyncConfig, raw map[string]interface{}) error {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		val, ok := raw[tag]
+		if !ok {
+			continue
+		}
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			s, err := cast.ToStringE(val)
+			if err != nil {
+				return errors.Wrapf(err, "field %s", tag)
+			}
+			field.SetString(s)
+		case reflect.Int, reflect.Int64:
+			n, err := cast.ToInt64E(val)
+			if err != nil {
+				return errors.Wrapf(err, "field %s", tag)
+			}
+			field.SetInt(n)
+		case reflect.Bool:
+			b, err := cast.ToBoolE(val)
+			if err != nil {
+				return errors.Wrapf(err, "field %s", tag)
+			}
+			field.SetBool(b)
+		default:
+			return errors.Errorf("unsupported config field kind %s for %s", field.Kind(), tag)
+		}
+	}
+
+	return nil
+}
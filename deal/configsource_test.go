@@ -0,0 +1,21 @@
+package deal
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnvSourceMultiWordField(t *testing.T) {
+	const want = "custom-posts"
+
+	os.Setenv("YUQUE_POSTPATH", want)
+	defer os.Unsetenv("YUQUE_POSTPATH")
+
+	cfg, err := LoadConfigLayered(DefaultsSource, EnvSource("YUQUE_"))
+	if err != nil {
+		t.Fatalf("LoadConfigLayered: %v", err)
+	}
+	if cfg.PostPath != want {
+		t.Errorf("PostPath = %q, want %q", cfg.PostPath, want)
+	}
+}
@@ -0,0 +1,46 @@
+package deal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wujiyu115/yuques/parser/metadecoders"
+)
+
+// ConvertFrontMatter walks postPath and rewrites the front matter of every
+// markdown file it finds into the given target format, in place. This
+// mirrors Hugo's "hugo convert" command and lets users migrate an existing
+// PostPath between YAML, TOML and JSON front matter without re-syncing.
+func ConvertFrontMatter(postPath string, to metadecoders.Format) error {
+	return filepath.Walk(postPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.ToLower(filepath.Ext(path)) != ".md" {
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		fm, err := ParseFrontMatter(content)
+		if err != nil {
+			return err
+		}
+		if fm.Format == "" {
+			// No front matter block to convert; leave the file untouched.
+			return nil
+		}
+
+		out, err := fm.Encode(to)
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(path, out, info.Mode())
+	})
+}
@@ -0,0 +1,155 @@
+package deal
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wujiyu115/yuques/parser/metadecoders"
+)
+
+// Front matter delimiters, as used by Hugo and most other static site
+// generators.
+const (
+	frontMatterYAMLDelim = "---"
+	frontMatterTOMLDelim = "+++"
+)
+
+// FrontMatter holds a document's front matter, decoded into Meta, together
+// with the markdown Body that followed it. Format records which of the
+// supported styles (YAML, TOML or JSON) the front matter was written in, and
+// is empty when no front matter block was found.
+type FrontMatter struct {
+	Format metadecoders.Format
+	Meta   map[string]interface{}
+	Body   string
+}
+
+// ParseFrontMatter detects and decodes a leading front matter block in
+// content: YAML delimited by "---", TOML delimited by "+++", or a bare JSON
+// object starting with "{". Content with no recognizable front matter is
+// returned with an empty Meta and the full content as Body, so callers can
+// always inject metadata and re-encode via Encode.
+func ParseFrontMatter(content []byte) (FrontMatter, error) {
+	s := string(bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF")))
+
+	switch {
+	case strings.HasPrefix(s, frontMatterYAMLDelim):
+		return parseDelimitedFrontMatter(s, frontMatterYAMLDelim, metadecoders.YAML)
+	case strings.HasPrefix(s, frontMatterTOMLDelim):
+		return parseDelimitedFrontMatter(s, frontMatterTOMLDelim, metadecoders.TOML)
+	case strings.HasPrefix(s, "{"):
+		return parseJSONFrontMatter(s)
+	default:
+		return FrontMatter{Meta: map[string]interface{}{}, Body: s}, nil
+	}
+}
+
+func parseDelimitedFrontMatter(s, delim string, format metadecoders.Format) (FrontMatter, error) {
+	rest := s[len(delim):]
+	nl := strings.IndexByte(rest, '\n')
+	if nl == -1 || strings.TrimSpace(rest[:nl]) != "" {
+		// Not actually a front matter line (e.g. a markdown "---" rule).
+		return FrontMatter{Meta: map[string]interface{}{}, Body: s}, nil
+	}
+	rest = rest[nl+1:]
+
+	end := strings.Index(rest, "\n"+delim)
+	if end == -1 {
+		return FrontMatter{}, errors.Errorf("unterminated %s front matter", delim)
+	}
+
+	raw := rest[:end]
+	body := strings.TrimPrefix(rest[end+len("\n"+delim):], "\n")
+
+	meta, err := metadecoders.Default.UnmarshalToMap([]byte(raw), format)
+	if err != nil {
+		return FrontMatter{}, err
+	}
+
+	return FrontMatter{Format: format, Meta: meta, Body: body}, nil
+}
+
+func parseJSONFrontMatter(s string) (FrontMatter, error) {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i, r := range s {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch r {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				raw := s[:i+1]
+				body := strings.TrimPrefix(s[i+1:], "\n")
+
+				meta, err := metadecoders.Default.UnmarshalToMap([]byte(raw), metadecoders.JSON)
+				if err != nil {
+					return FrontMatter{}, err
+				}
+
+				return FrontMatter{Format: metadecoders.JSON, Meta: meta, Body: body}, nil
+			}
+		}
+	}
+
+	return FrontMatter{}, errors.New("unterminated JSON front matter")
+}
+
+// Encode re-serializes fm.Meta in format f, falling back to fm.Format and
+// then YAML when f is empty, and re-assembles it with fm.Body into a full
+// document ready to be written back to disk.
+func (fm FrontMatter) Encode(f metadecoders.Format) ([]byte, error) {
+	format := f
+	if format == "" {
+		format = fm.Format
+	}
+	if format == "" {
+		format = metadecoders.YAML
+	}
+
+	handler, ok := metadecoders.HandlerFor(format)
+	if !ok {
+		return nil, errors.Errorf("unsupported front matter format %q", format)
+	}
+
+	raw, err := handler.Marshal(fm.Meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case metadecoders.YAML:
+		buf.WriteString(frontMatterYAMLDelim + "\n")
+		buf.Write(raw)
+		buf.WriteString(frontMatterYAMLDelim + "\n\n")
+	case metadecoders.TOML:
+		buf.WriteString(frontMatterTOMLDelim + "\n")
+		buf.Write(raw)
+		buf.WriteString(frontMatterTOMLDelim + "\n\n")
+	default:
+		buf.Write(raw)
+		buf.WriteString("\n\n")
+	}
+	buf.WriteString(fm.Body)
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package deal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wujiyu115/yuques/parser/metadecoders"
+)
+
+func TestParseFrontMatterYAML(t *testing.T) {
+	content := []byte("---\ntitle: Hello\nslug: hello\n---\nbody text\n")
+
+	fm, err := ParseFrontMatter(content)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter: %v", err)
+	}
+	if fm.Format != metadecoders.YAML {
+		t.Errorf("Format = %q, want yaml", fm.Format)
+	}
+	if fm.Meta["title"] != "Hello" {
+		t.Errorf("title = %v", fm.Meta["title"])
+	}
+	if fm.Body != "body text\n" {
+		t.Errorf("Body = %q", fm.Body)
+	}
+}
+
+func TestParseFrontMatterTOML(t *testing.T) {
+	content := []byte("+++\ntitle = \"Hello\"\n+++\nbody text\n")
+
+	fm, err := ParseFrontMatter(content)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter: %v", err)
+	}
+	if fm.Format != metadecoders.TOML {
+		t.Errorf("Format = %q, want toml", fm.Format)
+	}
+	if fm.Meta["title"] != "Hello" {
+		t.Errorf("title = %v", fm.Meta["title"])
+	}
+}
+
+func TestParseFrontMatterJSONQuotedBrace(t *testing.T) {
+	content := []byte(`{"title": "a } b", "slug": "x"}` + "\nbody text")
+
+	fm, err := ParseFrontMatter(content)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter: %v", err)
+	}
+	if fm.Meta["title"] != "a } b" {
+		t.Errorf("title = %v, want %q", fm.Meta["title"], "a } b")
+	}
+	if fm.Body != "body text" {
+		t.Errorf("Body = %q", fm.Body)
+	}
+}
+
+func TestParseFrontMatterNone(t *testing.T) {
+	content := []byte("just a plain markdown file\n")
+
+	fm, err := ParseFrontMatter(content)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter: %v", err)
+	}
+	if fm.Format != "" {
+		t.Errorf("Format = %q, want empty", fm.Format)
+	}
+	if fm.Body != string(content) {
+		t.Errorf("Body = %q", fm.Body)
+	}
+}
+
+func TestFrontMatterEncodeRoundTrip(t *testing.T) {
+	original := []byte("---\ntitle: Hello\n---\nbody text\n")
+
+	fm, err := ParseFrontMatter(original)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter: %v", err)
+	}
+
+	out, err := fm.Encode(metadecoders.TOML)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !strings.HasPrefix(string(out), frontMatterTOMLDelim+"\n") {
+		t.Fatalf("Encode did not use TOML delimiters: %q", out)
+	}
+
+	reparsed, err := ParseFrontMatter(out)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter(re-encoded): %v", err)
+	}
+	if reparsed.Meta["title"] != "Hello" {
+		t.Errorf("round-tripped title = %v", reparsed.Meta["title"])
+	}
+	if reparsed.Body != "\n"+fm.Body {
+		t.Errorf("round-tripped body = %q, want %q", reparsed.Body, "\n"+fm.Body)
+	}
+}
@@ -14,15 +14,12 @@
 package metadecoders
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
-	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"github.com/spf13/cast"
-	yaml "gopkg.in/yaml.v2"
 )
 
 // Decoder provides some configuration options for the decoders.
@@ -84,7 +81,10 @@ func (d Decoder) UnmarshalStringTo(data string, typ interface{}) (interface{}, e
 	case string:
 		return data, nil
 	case map[string]interface{}:
-		format := d.FormatFromContentString(data)
+		format, err := d.FormatFromContentString(data)
+		if err != nil {
+			return nil, err
+		}
 		return d.UnmarshalToMap([]byte(data), format)
 	case []interface{}:
 		// A standalone slice. Let YAML handle it.
@@ -114,18 +114,18 @@ func (d Decoder) Unmarshal(data []byte, f Format) (interface{}, error) {
 	return v, err
 }
 
-// unmarshal unmarshals data in format f into v.
+// unmarshal unmarshals data in format f into v, dispatching to whatever
+// handler was registered for f via RegisterFormat.
 func (d Decoder) unmarshal(data []byte, f Format, v interface{}) error {
 
-	var err error
+	handler, ok := formatHandlers[f]
+	if !ok {
+		return errors.Errorf("unmarshal of format %q is not supported", f)
+	}
+
+	err := handler.Unmarshal(data, v)
 
-	switch f {
-	case JSON:
-		err = json.Unmarshal(data, v)
-	case TOML:
-		err = toml.Unmarshal(data, v)
-	case YAML:
-		err = yaml.Unmarshal(data, v)
+	if f == YAML {
 		if err != nil {
 			return toFileError(f, errors.Wrap(err, "failed to unmarshal YAML"))
 		}
@@ -152,9 +152,6 @@ func (d Decoder) unmarshal(data []byte, f Format, v interface{}) error {
 				*v.(*interface{}) = mm
 			}
 		}
-
-	default:
-		return errors.Errorf("unmarshal of format %q is not supported", f)
 	}
 
 	if err == nil {
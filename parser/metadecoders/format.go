@@ -14,8 +14,14 @@
 package metadecoders
 
 import (
+	"bytes"
+	"encoding/json"
 	"path/filepath"
 	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
 )
 
 type Format string
@@ -28,6 +34,81 @@ const (
 	YAML Format = "yaml"
 )
 
+// FormatHandler describes how to decode and encode a metadata Format, and
+// which file extensions identify it. Registering a FormatHandler via
+// RegisterFormat is what makes a format usable by UnmarshalToMap, Unmarshal
+// and FormatFromString without touching any of their internals.
+type FormatHandler struct {
+	Format     Format
+	Extensions []string
+	Unmarshal  func([]byte, interface{}) error
+	Marshal    func(interface{}) ([]byte, error)
+}
+
+var (
+	formatHandlers = make(map[Format]FormatHandler)
+	formatOrder    []Format
+)
+
+func init() {
+	RegisterFormat(string(JSON), []string{"json"}, func(data []byte, v interface{}) error {
+		return json.Unmarshal(data, v)
+	}, func(v interface{}) ([]byte, error) {
+		return json.MarshalIndent(v, "", "  ")
+	})
+
+	RegisterFormat(string(YAML), []string{"yaml", "yml"}, func(data []byte, v interface{}) error {
+		return yaml.Unmarshal(data, v)
+	}, func(v interface{}) ([]byte, error) {
+		return yaml.Marshal(v)
+	})
+
+	RegisterFormat(string(TOML), []string{"toml"}, func(data []byte, v interface{}) error {
+		return toml.Unmarshal(data, v)
+	}, func(v interface{}) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// RegisterFormat registers a metadata format under name, along with the file
+// extensions it is recognized by and the functions used to decode/encode it.
+// Registering a name that is already known replaces its handler, so callers
+// may use it to add formats such as ORG, XML or HCL front matter, or to
+// override one of the built-in JSON/TOML/YAML handlers, without touching the
+// decoding logic in this package.
+func RegisterFormat(name string, exts []string, unmarshal func([]byte, interface{}) error, marshal func(interface{}) ([]byte, error)) {
+	f := Format(name)
+	if _, exists := formatHandlers[f]; !exists {
+		formatOrder = append(formatOrder, f)
+	}
+	formatHandlers[f] = FormatHandler{
+		Format:     f,
+		Extensions: exts,
+		Unmarshal:  unmarshal,
+		Marshal:    marshal,
+	}
+}
+
+// RegisteredFormats returns the currently registered formats, in the order
+// they were registered.
+func RegisteredFormats() []FormatHandler {
+	handlers := make([]FormatHandler, 0, len(formatOrder))
+	for _, f := range formatOrder {
+		handlers = append(handlers, formatHandlers[f])
+	}
+	return handlers
+}
+
+// HandlerFor returns the FormatHandler registered for f, if any.
+func HandlerFor(f Format) (FormatHandler, bool) {
+	h, ok := formatHandlers[f]
+	return h, ok
+}
+
 // FormatFromString turns formatStr, typically a file extension without any ".",
 // into a Format. It returns an empty string for unknown formats.
 func FormatFromString(formatStr string) Format {
@@ -37,54 +118,143 @@ func FormatFromString(formatStr string) Format {
 		formatStr = strings.TrimPrefix(filepath.Ext(formatStr), ".")
 
 	}
-	switch formatStr {
-	case "yaml", "yml":
-		return YAML
-	case "json":
-		return JSON
-	case "toml":
-		return TOML
+
+	for _, h := range RegisteredFormats() {
+		for _, ext := range h.Extensions {
+			if ext == formatStr {
+				return h.Format
+			}
+		}
 	}
 
 	return ""
 
 }
 
-// FormatFromFrontMatterType will return empty if not supported.
-func FormatFromFrontMatterType(typ ItemType) Format {
-	switch typ {
-	case TypeFrontMatterJSON:
-		return JSON
-	case TypeFrontMatterTOML:
-		return TOML
-	case TypeFrontMatterYAML:
-		return YAML
-	default:
-		return ""
+// FormatFromContentString tries to detect the format (JSON, YAML or TOML) of
+// the given string: a leading "{" is taken as JSON, as is a leading "["
+// unless it looks like a TOML table header (see looksLikeTOMLTableHeader); a
+// leading "+++" or a top-level "key = value" line is taken as TOML, and a
+// leading "---" or a top-level "key:" line as YAML. Text inside comments
+// (introduced by "#") and quoted strings is ignored so that a value such as
+// `title = "a: b"` isn't mistaken for YAML. It returns an error listing
+// every format that was considered when none of them look like a match.
+func (d Decoder) FormatFromContentString(data string) (Format, error) {
+	trimmed := strings.TrimLeft(data, " \t\r\n")
+
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		return JSON, nil
+	case strings.HasPrefix(trimmed, "["):
+		if looksLikeTOMLTableHeader(trimmed) {
+			return TOML, nil
+		}
+		return JSON, nil
+	case strings.HasPrefix(trimmed, "+++"):
+		return TOML, nil
+	case strings.HasPrefix(trimmed, "---"):
+		return YAML, nil
 	}
-}
 
-// FormatFromContentString tries to detect the format (JSON, YAML or TOML)
-// in the given string.
-// It return an empty string if no format could be detected.
-func (d Decoder) FormatFromContentString(data string) Format {
-	jsonIdx := strings.Index(data, "{")
-	yamlIdx := strings.Index(data, ":")
-	tomlIdx := strings.Index(data, "=")
+	jsonIdx, yamlIdx, tomlIdx := indicesOutsideCommentsAndStrings(data)
 
 	if isLowerIndexThan(jsonIdx, yamlIdx, tomlIdx) {
-		return JSON
+		return JSON, nil
 	}
 
 	if isLowerIndexThan(yamlIdx, tomlIdx) {
-		return YAML
+		return YAML, nil
 	}
 
 	if tomlIdx != -1 {
-		return TOML
+		return TOML, nil
 	}
 
-	return ""
+	return "", errors.Errorf("unable to detect a configuration format (tried json, yaml, toml) in content starting with %q", truncate(trimmed, 40))
+}
+
+// looksLikeTOMLTableHeader reports whether s starts with a TOML table
+// header such as "[section]" or "[[section]]" - a single- or double-
+// bracketed dotted identifier alone on its first line - as opposed to a
+// JSON array, which would contain a comma, a colon, quotes around multiple
+// tokens, or span multiple lines before closing.
+func looksLikeTOMLTableHeader(s string) bool {
+	line := s
+	if nl := strings.IndexByte(s, '\n'); nl != -1 {
+		line = s[:nl]
+	}
+	line = strings.TrimSpace(line)
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "[["), "]]")
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+
+	if line == "" {
+		return false
+	}
+
+	for _, r := range line {
+		switch {
+		case r == '.' || r == '_' || r == '-' || r == '"' || r == '\'':
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// indicesOutsideCommentsAndStrings returns the first index of "{", ":" and
+// "=" in data, ignoring any that appear inside a '#' comment (running to the
+// end of the line) or inside a single- or double-quoted string.
+func indicesOutsideCommentsAndStrings(data string) (jsonIdx, yamlIdx, tomlIdx int) {
+	jsonIdx, yamlIdx, tomlIdx = -1, -1, -1
+
+	var quote rune
+	inComment := false
+
+	for i, r := range data {
+		if inComment {
+			if r == '\n' {
+				inComment = false
+			}
+			continue
+		}
+
+		if quote != 0 {
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '\'', '"':
+			quote = r
+		case '#':
+			inComment = true
+		case '{':
+			if jsonIdx == -1 {
+				jsonIdx = i
+			}
+		case ':':
+			if yamlIdx == -1 {
+				yamlIdx = i
+			}
+		case '=':
+			if tomlIdx == -1 {
+				tomlIdx = i
+			}
+		}
+	}
+
+	return
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
 }
 
 func isLowerIndexThan(first int, others ...int) bool {
@@ -0,0 +1,63 @@
+package metadecoders
+
+import "testing"
+
+func TestFormatFromContentStringDelimited(t *testing.T) {
+	cases := map[string]Format{
+		`{"title": "hello"}`:          JSON,
+		"+++\ntitle = \"hello\"\n+++": TOML,
+		"---\ntitle: hello\n---":      YAML,
+	}
+	for in, want := range cases {
+		got, err := Default.FormatFromContentString(in)
+		if err != nil {
+			t.Errorf("FormatFromContentString(%q): %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("FormatFromContentString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatFromContentStringTOMLTableHeaderVsJSONArray(t *testing.T) {
+	cases := map[string]Format{
+		"[section]\nkey = \"value\"": TOML,
+		"[[repos]]\nlogin = \"a\"":   TOML,
+		`["a", "b", "c"]`:            JSON,
+		"[\n  \"a\",\n  \"b\"\n]":    JSON,
+	}
+	for in, want := range cases {
+		got, err := Default.FormatFromContentString(in)
+		if err != nil {
+			t.Errorf("FormatFromContentString(%q): %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("FormatFromContentString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatFromContentStringBareKeyValue(t *testing.T) {
+	cases := map[string]Format{
+		"title = \"a: b\"\ndate = 2020": TOML,
+		"title: hello\nslug: world":     YAML,
+	}
+	for in, want := range cases {
+		got, err := Default.FormatFromContentString(in)
+		if err != nil {
+			t.Errorf("FormatFromContentString(%q): %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("FormatFromContentString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatFromContentStringUnrecognized(t *testing.T) {
+	if _, err := Default.FormatFromContentString("just some plain text"); err == nil {
+		t.Fatalf("expected an error for unrecognized content")
+	}
+}
@@ -0,0 +1,57 @@
+package metadecoders
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegisterFormatRoundTrip(t *testing.T) {
+	RegisterFormat("csv-test", []string{"csvtest"}, func(data []byte, v interface{}) error {
+		m, ok := v.(*map[string]interface{})
+		if !ok {
+			return nil
+		}
+		*m = map[string]interface{}{"raw": string(data)}
+		return nil
+	}, func(v interface{}) ([]byte, error) {
+		return []byte("csv-test"), nil
+	})
+
+	if FormatFromString("config.csvtest") != Format("csv-test") {
+		t.Fatalf("FormatFromString did not resolve the registered extension")
+	}
+
+	handler, ok := HandlerFor(Format("csv-test"))
+	if !ok {
+		t.Fatalf("HandlerFor did not find the registered format")
+	}
+
+	m, err := Default.UnmarshalToMap([]byte("hello"), handler.Format)
+	if err != nil {
+		t.Fatalf("UnmarshalToMap: %v", err)
+	}
+	if !reflect.DeepEqual(m, map[string]interface{}{"raw": "hello"}) {
+		t.Errorf("got %v", m)
+	}
+
+	out, err := handler.Marshal(m)
+	if err != nil || string(out) != "csv-test" {
+		t.Errorf("Marshal = %q, %v", out, err)
+	}
+}
+
+func TestFormatFromStringBuiltins(t *testing.T) {
+	cases := map[string]Format{
+		"config.json": JSON,
+		"config.yaml": YAML,
+		"config.yml":  YAML,
+		"config.toml": TOML,
+		"json":        JSON,
+		"unknown":     "",
+	}
+	for in, want := range cases {
+		if got := FormatFromString(in); got != want {
+			t.Errorf("FormatFromString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}